@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/Atis9/slack2voice/internal/tts"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// controlServer exposes an optional embedded HTTP endpoint for health
+// checks, Prometheus-style metrics, manual TTS triggers, and config reloads.
+// It is only started when HTTP_LISTEN_ADDR is set.
+type controlServer struct {
+	addr       string
+	secret     string
+	queue      *playbackQueue
+	cfg        *Config
+	slackAPI   *slack.Client
+	engine     tts.Engine
+	msgMetrics *messageMetrics
+
+	srv *http.Server
+}
+
+func newControlServer(addr, secret string, queue *playbackQueue, cfg *Config, slackAPI *slack.Client, engine tts.Engine, msgMetrics *messageMetrics) *controlServer {
+	s := &controlServer{
+		addr:       addr,
+		secret:     secret,
+		queue:      queue,
+		cfg:        cfg,
+		slackAPI:   slackAPI,
+		engine:     engine,
+		msgMetrics: msgMetrics,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("POST /say", s.handleSay)
+	mux.HandleFunc("POST /reload", s.handleReload)
+	s.srv = &http.Server{Addr: addr, Handler: mux}
+
+	return s
+}
+
+// Start begins serving in the background. It returns the error if
+// ListenAndServe fails within 200ms (e.g. the address cannot be bound), and
+// nil otherwise; a failure after that window is not reported.
+func (s *controlServer) Start() error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("INFO: Control server listening on %s", s.addr)
+		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("control server failed to start: %w", err)
+	case <-time.After(200 * time.Millisecond):
+		return nil
+	}
+}
+
+// handleHealthz reports unhealthy (503) if the Slack token is no longer
+// valid or the configured TTS backend is unreachable, so that a k8s
+// liveness/readiness probe can detect and act on either failure mode.
+func (s *controlServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	var problems []string
+
+	if _, err := s.slackAPI.AuthTestContext(ctx); err != nil {
+		problems = append(problems, fmt.Sprintf("slack auth: %v", err))
+	}
+
+	if checker, ok := s.engine.(tts.HealthChecker); ok {
+		if err := checker.CheckHealth(ctx); err != nil {
+			problems = append(problems, fmt.Sprintf("tts engine: %v", err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		for _, p := range problems {
+			fmt.Fprintln(w, p)
+		}
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *controlServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	m := s.queue.Metrics()
+
+	muted := 0
+	if m.Muted {
+		muted = 1
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintf(w, "# HELP slack2voice_queue_length Number of utterances currently waiting to be played.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_queue_length gauge\n")
+	fmt.Fprintf(w, "slack2voice_queue_length %d\n", m.QueueLength)
+	fmt.Fprintf(w, "# HELP slack2voice_queue_muted Whether playback is currently paused via /mute.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_queue_muted gauge\n")
+	fmt.Fprintf(w, "slack2voice_queue_muted %d\n", muted)
+	fmt.Fprintf(w, "# HELP slack2voice_jobs_enqueued_total Total utterances enqueued for playback.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_jobs_enqueued_total counter\n")
+	fmt.Fprintf(w, "slack2voice_jobs_enqueued_total %d\n", m.JobsEnqueued)
+	fmt.Fprintf(w, "# HELP slack2voice_jobs_dropped_total Total utterances dropped due to the queue exceeding its max length.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_jobs_dropped_total counter\n")
+	fmt.Fprintf(w, "slack2voice_jobs_dropped_total %d\n", m.JobsDropped)
+	fmt.Fprintf(w, "# HELP slack2voice_jobs_processed_total Total utterances successfully played.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_jobs_processed_total counter\n")
+	fmt.Fprintf(w, "slack2voice_jobs_processed_total %d\n", m.JobsProcessed)
+	fmt.Fprintf(w, "# HELP slack2voice_jobs_failed_total Total utterances that failed to synthesize or play.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_jobs_failed_total counter\n")
+	fmt.Fprintf(w, "slack2voice_jobs_failed_total %d\n", m.JobsFailed)
+
+	mm := s.msgMetrics.Snapshot()
+	fmt.Fprintf(w, "# HELP slack2voice_messages_received_total Total Slack message events received, before the USER_IDS/CHANNEL_IDS allow-list is applied.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_messages_received_total counter\n")
+	fmt.Fprintf(w, "slack2voice_messages_received_total %d\n", mm.Received)
+	fmt.Fprintf(w, "# HELP slack2voice_messages_filtered_total Total Slack message events dropped by the USER_IDS/CHANNEL_IDS allow-list.\n")
+	fmt.Fprintf(w, "# TYPE slack2voice_messages_filtered_total counter\n")
+	fmt.Fprintf(w, "slack2voice_messages_filtered_total %d\n", mm.Filtered)
+
+	if provider, ok := s.engine.(tts.MetricsProvider); ok {
+		writeHistogram(w, "slack2voice_tts_audio_query_seconds", "Latency of audio_query HTTP calls to the TTS engine.", provider.AudioQueryLatency())
+		writeHistogram(w, "slack2voice_tts_synthesis_seconds", "Latency of synthesis HTTP calls to the TTS engine.", provider.SynthesisLatency())
+	}
+}
+
+// writeHistogram renders a tts.HistogramSnapshot in Prometheus text
+// exposition format: cumulative "_bucket" lines (including the implicit
+// "+Inf" bucket), plus "_sum" and "_count".
+func writeHistogram(w http.ResponseWriter, name, help string, snap tts.HistogramSnapshot) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for i, bound := range snap.Bounds {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", name, bound, snap.BucketCounts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, snap.Count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, snap.SumSeconds)
+	fmt.Fprintf(w, "%s_count %d\n", name, snap.Count)
+}
+
+// sayRequest is the JSON body accepted by POST /say.
+type sayRequest struct {
+	Text            string   `json:"text"`
+	Channel         string   `json:"channel,omitempty"`
+	TimeStamp       string   `json:"timestamp,omitempty"`
+	SpeakerID       string   `json:"speaker_id,omitempty"`
+	SpeedScale      *float64 `json:"speed_scale,omitempty"`
+	PitchScale      *float64 `json:"pitch_scale,omitempty"`
+	IntonationScale *float64 `json:"intonation_scale,omitempty"`
+	VolumeScale     *float64 `json:"volume_scale,omitempty"`
+}
+
+func (s *controlServer) handleSay(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req sayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Text == "" {
+		http.Error(w, "text must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	s.queue.Enqueue(&synthJob{
+		UserID:    "http-control",
+		Channel:   req.Channel,
+		TimeStamp: req.TimeStamp,
+		Text:      req.Text,
+		Opts: tts.SynthesizeOptions{
+			SpeakerID:       req.SpeakerID,
+			SpeedScale:      req.SpeedScale,
+			PitchScale:      req.PitchScale,
+			IntonationScale: req.IntonationScale,
+			VolumeScale:     req.VolumeScale,
+		},
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(w, "queued")
+}
+
+func (s *controlServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.cfg.Reload(); err != nil {
+		http.Error(w, fmt.Sprintf("reload failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "reloaded")
+}
+
+// authorized checks the shared secret supplied via the Authorization: Bearer
+// header against the one configured via HTTP_CONTROL_SECRET. The comparison
+// runs in constant time so a timing side-channel can't be used to guess the
+// secret byte-by-byte.
+func (s *controlServer) authorized(r *http.Request) bool {
+	expected := "Bearer " + s.secret
+	actual := r.Header.Get("Authorization")
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+
+// Shutdown gracefully stops the control server.
+func (s *controlServer) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
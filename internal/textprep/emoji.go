@@ -0,0 +1,29 @@
+package textprep
+
+// emojiReadings maps common :emoji_name: shorthand to a short spoken
+// reading, similar in spirit to peterhellberg/emojilib's name table but
+// trimmed to the handful of reactions actually seen in day-to-day Slack
+// chatter. Names with no entry are dropped rather than read aloud verbatim.
+var emojiReadings = map[string]string{
+	"smile":            "笑顔",
+	"smiley":           "笑顔",
+	"laughing":         "笑い",
+	"joy":              "大笑い",
+	"wink":             "ウインク",
+	"thumbsup":         "グッド",
+	"+1":               "グッド",
+	"thumbsdown":       "バッド",
+	"-1":               "バッド",
+	"clap":             "拍手",
+	"pray":             "お願い",
+	"tada":             "パチパチ",
+	"fire":             "いいね",
+	"heart":            "ハート",
+	"eyes":             "目",
+	"thinking":         "考え中",
+	"sob":              "号泣",
+	"cry":              "涙",
+	"wave":             "手を振る",
+	"white_check_mark": "チェック",
+	"warning":          "警告",
+}
@@ -0,0 +1,70 @@
+package textprep
+
+import (
+	"context"
+	"log"
+
+	"github.com/slack-go/slack"
+)
+
+// UserInfoGetter is the subset of *slack.Client used to resolve <@Uxxx>
+// mentions, kept as an interface so tests can supply a fake.
+type UserInfoGetter interface {
+	GetUserInfoContext(ctx context.Context, user string) (*slack.User, error)
+}
+
+// ChannelInfoGetter is the subset of *slack.Client used to resolve bare
+// <#Cxxx> mentions, kept as an interface so tests can supply a fake.
+type ChannelInfoGetter interface {
+	GetConversationInfoContext(ctx context.Context, input *slack.GetConversationInfoInput) (*slack.Channel, error)
+}
+
+func (r *Renderer) resolveUser(ctx context.Context, userID string) string {
+	cacheKey := "user:" + userID
+	if name, ok := r.cache.get(cacheKey); ok {
+		return name
+	}
+
+	if r.users == nil {
+		return "@" + userID
+	}
+
+	userInfo, err := r.users.GetUserInfoContext(ctx, userID)
+	if err != nil {
+		log.Printf("WARNING: textprep: failed to resolve user %s: %v", userID, err)
+		return "@" + userID
+	}
+
+	displayName := userInfo.Profile.DisplayName
+	if displayName == "" {
+		displayName = userInfo.RealName
+	}
+	if displayName == "" {
+		displayName = userInfo.Name
+	}
+
+	rendered := "@" + displayName + "さん"
+	r.cache.set(cacheKey, rendered)
+	return rendered
+}
+
+func (r *Renderer) resolveChannel(ctx context.Context, channelID string) string {
+	cacheKey := "channel:" + channelID
+	if name, ok := r.cache.get(cacheKey); ok {
+		return name
+	}
+
+	if r.channels == nil {
+		return "#" + channelID
+	}
+
+	channelInfo, err := r.channels.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		log.Printf("WARNING: textprep: failed to resolve channel %s: %v", channelID, err)
+		return "#" + channelID
+	}
+
+	rendered := "#" + channelInfo.Name
+	r.cache.set(cacheKey, rendered)
+	return rendered
+}
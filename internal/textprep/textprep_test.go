@@ -0,0 +1,151 @@
+package textprep
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+type fakeUserGetter struct {
+	users map[string]*slack.User
+}
+
+func (f fakeUserGetter) GetUserInfoContext(_ context.Context, user string) (*slack.User, error) {
+	u, ok := f.users[user]
+	if !ok {
+		return nil, errNotFound
+	}
+	return u, nil
+}
+
+type fakeChannelGetter struct {
+	channels map[string]*slack.Channel
+}
+
+func (f fakeChannelGetter) GetConversationInfoContext(_ context.Context, input *slack.GetConversationInfoInput) (*slack.Channel, error) {
+	c, ok := f.channels[input.ChannelID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return c, nil
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+var errNotFound = notFoundError{}
+
+func newTestRenderer() *Renderer {
+	users := fakeUserGetter{users: map[string]*slack.User{
+		"U123": {ID: "U123", Name: "taro", Profile: slack.UserProfile{DisplayName: "太郎"}},
+	}}
+	channels := fakeChannelGetter{channels: map[string]*slack.Channel{}}
+	channels.channels["C456"] = &slack.Channel{
+		GroupConversation: slack.GroupConversation{
+			Conversation: slack.Conversation{ID: "C456"},
+			Name:         "general",
+		},
+	}
+	return New(users, channels, Options{})
+}
+
+func TestRender_UserMention(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<@U123> こんにちは")
+	want := "@太郎さん こんにちは"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_UserMentionUnknown(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<@U999>")
+	want := "@U999"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_ChannelMentionWithLabel(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<#C456|general>")
+	want := "#general"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_BareChannelMention(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<#C456>")
+	want := "#general"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_SpecialMention(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<!here> 緊急です")
+	want := "@here 緊急です"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_SpecialMentionWithLabel(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "<!subteam^S123|@team-name> お疲れ様です")
+	want := "@team-name お疲れ様です"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_GenericLinkFallsBackToLabelOrEmpty(t *testing.T) {
+	r := newTestRenderer()
+	if got, want := r.Render(context.Background(), "<https://example.com|資料>"), "資料"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if got, want := r.Render(context.Background(), "<https://example.com>"), ""; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_Emoji(t *testing.T) {
+	r := newTestRenderer()
+	if got, want := r.Render(context.Background(), "やった:tada:"), "やったパチパチ"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if got, want := r.Render(context.Background(), ":unknown_emoji_xyz:"), ""; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_EmojiStripMode(t *testing.T) {
+	r := New(nil, nil, Options{EmojiMode: EmojiModeStrip})
+	if got, want := r.Render(context.Background(), "やった:tada:"), "やった"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_CodeBlock(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "見て\n```func main() {}```\nおわり")
+	want := "見て\n" + codeBlockPlaceholder + "\nおわり"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRender_HTMLEntities(t *testing.T) {
+	r := newTestRenderer()
+	got := r.Render(context.Background(), "A &amp; B &lt;tag&gt;")
+	want := "A & B <tag>"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package tts
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// defaultLatencyBuckets are the upper bounds (in seconds) used for the
+// audio_query/synthesis latency histograms exposed via GET /metrics. They
+// mirror the Prometheus client library's own defaults, which are broad
+// enough to cover either a local VOICEVOX instance or a remote one.
+var defaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// latencyHistogram is a minimal, dependency-free latency histogram shaped
+// for Prometheus text exposition (cumulative "_bucket" counts plus "_sum"
+// and "_count"), so the control server's hand-rolled /metrics endpoint can
+// report sub-call timings without pulling in the full client_golang
+// library.
+type latencyHistogram struct {
+	bounds   []float64 // upper bounds in seconds, ascending, excluding +Inf
+	buckets  []atomic.Int64
+	sumNanos atomic.Int64
+	count    atomic.Int64
+}
+
+func newLatencyHistogram(bounds []float64) *latencyHistogram {
+	return &latencyHistogram{
+		bounds:  bounds,
+		buckets: make([]atomic.Int64, len(bounds)),
+	}
+}
+
+// Observe records a single latency sample.
+func (h *latencyHistogram) Observe(d time.Duration) {
+	seconds := d.Seconds()
+	for i, bound := range h.bounds {
+		if seconds <= bound {
+			h.buckets[i].Add(1)
+		}
+	}
+	h.sumNanos.Add(d.Nanoseconds())
+	h.count.Add(1)
+}
+
+// HistogramSnapshot is a point-in-time read of a latencyHistogram, used by
+// the control server's /metrics endpoint.
+type HistogramSnapshot struct {
+	Bounds       []float64 // upper bounds in seconds, ascending
+	BucketCounts []int64   // cumulative count of observations <= Bounds[i]
+	SumSeconds   float64
+	Count        int64
+}
+
+func (h *latencyHistogram) Snapshot() HistogramSnapshot {
+	counts := make([]int64, len(h.buckets))
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+	}
+	return HistogramSnapshot{
+		Bounds:       h.bounds,
+		BucketCounts: counts,
+		SumSeconds:   time.Duration(h.sumNanos.Load()).Seconds(),
+		Count:        h.count.Load(),
+	}
+}
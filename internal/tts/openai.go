@@ -0,0 +1,102 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const openAIAudioSpeechURL = "https://api.openai.com/v1/audio/speech"
+
+// openAIEngine drives OpenAI's /v1/audio/speech endpoint.
+type openAIEngine struct {
+	apiKey     string
+	model      string
+	voice      string
+	httpClient *http.Client
+}
+
+// NewOpenAIEngine constructs an Engine backed by the OpenAI TTS API. model
+// and voice fall back to "tts-1" and "alloy" respectively when empty.
+func NewOpenAIEngine(apiKey, model, voice string) Engine {
+	if model == "" {
+		model = "tts-1"
+	}
+	if voice == "" {
+		voice = "alloy"
+	}
+	return &openAIEngine{
+		apiKey: apiKey,
+		model:  model,
+		voice:  voice,
+		httpClient: &http.Client{
+			Timeout: httpClientTimeout,
+		},
+	}
+}
+
+func (e *openAIEngine) Name() string { return "openai" }
+
+type openAISpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format"`
+	Speed          float64 `json:"speed,omitempty"`
+}
+
+func (e *openAIEngine) Synthesize(ctx context.Context, text string, opts SynthesizeOptions) (*SynthesisResult, error) {
+	voice := e.voice
+	if opts.SpeakerID != "" {
+		voice = opts.SpeakerID
+	}
+
+	reqBody := openAISpeechRequest{
+		Model:          e.model,
+		Input:          text,
+		Voice:          voice,
+		ResponseFormat: "wav",
+	}
+	if opts.SpeedScale != nil {
+		reqBody.Speed = *opts.SpeedScale
+	}
+	// PitchScale, IntonationScale and VolumeScale have no OpenAI equivalent
+	// and are silently ignored.
+
+	bodyJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", openAIAudioSpeechURL, bytes.NewReader(bodyJSON))
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai: request execution failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("openai: API error: status %s, body: %s", res.Status, string(bodyBytes))
+	}
+
+	wavData, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to read response body: %w", err)
+	}
+
+	result, err := ParseWAV(wavData)
+	if err != nil {
+		return nil, fmt.Errorf("openai: failed to parse synthesized WAV: %w", err)
+	}
+	return result, nil
+}
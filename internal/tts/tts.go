@@ -0,0 +1,149 @@
+// Package tts provides a pluggable text-to-speech engine abstraction so that
+// slack2voice is not hardwired to a single VOICEVOX-compatible backend.
+package tts
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// Kind identifies which Engine implementation to construct.
+type Kind string
+
+const (
+	KindVoicevox    Kind = "voicevox"
+	KindCoeiroInk   Kind = "coeiroink"
+	KindAivisSpeech Kind = "aivisspeech"
+	KindOpenAI      Kind = "openai"
+	KindElevenLabs  Kind = "elevenlabs"
+)
+
+// SynthesizeOptions carries per-request synthesis parameters. Fields left nil
+// mean "use the engine's default"; not every engine honors every field.
+type SynthesizeOptions struct {
+	SpeakerID       string
+	SpeedScale      *float64
+	PitchScale      *float64
+	IntonationScale *float64
+	VolumeScale     *float64
+}
+
+// SynthesisResult is raw, ready-to-play PCM audio plus the format metadata
+// needed to (re)configure the audio output device.
+type SynthesisResult struct {
+	PCM           []byte
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// Engine synthesizes speech for a piece of text.
+type Engine interface {
+	// Name identifies the engine for logging.
+	Name() string
+	Synthesize(ctx context.Context, text string, opts SynthesizeOptions) (*SynthesisResult, error)
+}
+
+// HealthChecker is an optional capability of an Engine that can verify its
+// backend is reachable without performing a full synthesis, e.g. for use by
+// a liveness/readiness probe. Engines with no separate backend to probe
+// (e.g. OpenAI, whose reachability is covered by normal request latency)
+// need not implement it.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// MetricsProvider is an optional capability of an Engine that tracks latency
+// histograms for its own HTTP sub-calls, e.g. a VOICEVOX-compatible engine's
+// audio_query and synthesis requests. Engines whose Synthesize is a single
+// round trip (e.g. OpenAI) need not implement it.
+type MetricsProvider interface {
+	AudioQueryLatency() HistogramSnapshot
+	SynthesisLatency() HistogramSnapshot
+}
+
+// Config bundles the settings every engine constructor might need. Only the
+// fields relevant to the selected Kind must be populated.
+type Config struct {
+	VoicevoxEndpoint string
+	VoicevoxSpeaker  string
+
+	AivisSpeechEndpoint string
+	AivisSpeechSpeaker  string
+
+	OpenAIAPIKey string
+	OpenAIModel  string
+	OpenAIVoice  string
+}
+
+// New constructs the engine identified by kind.
+func New(kind Kind, cfg Config) (Engine, error) {
+	switch kind {
+	case KindVoicevox:
+		return newVoicevoxCompatibleEngine("voicevox", cfg.VoicevoxEndpoint, cfg.VoicevoxSpeaker), nil
+	case KindAivisSpeech:
+		return newVoicevoxCompatibleEngine("aivisspeech", cfg.AivisSpeechEndpoint, cfg.AivisSpeechSpeaker), nil
+	case KindOpenAI:
+		return NewOpenAIEngine(cfg.OpenAIAPIKey, cfg.OpenAIModel, cfg.OpenAIVoice), nil
+	case KindCoeiroInk, KindElevenLabs:
+		return nil, fmt.Errorf("tts: engine %q is not implemented yet", kind)
+	default:
+		return nil, fmt.Errorf("tts: unknown TTS_ENGINE %q", kind)
+	}
+}
+
+// ParseWAV locates the "fmt " and "data" sub-chunks of a RIFF/WAVE byte
+// stream and returns the decoded PCM payload plus format metadata. Not every
+// engine emits a fixed 44-byte header, so callers must not assume one.
+func ParseWAV(wav []byte) (*SynthesisResult, error) {
+	if len(wav) < 12 || string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("tts: not a RIFF/WAVE stream")
+	}
+
+	var (
+		res     SynthesisResult
+		sawFmt  bool
+		sawData bool
+	)
+
+	offset := 12
+	for offset+8 <= len(wav) {
+		chunkID := string(wav[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(wav[offset+4 : offset+8]))
+		dataStart := offset + 8
+		if dataStart+chunkSize > len(wav) {
+			break
+		}
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return nil, fmt.Errorf("tts: fmt chunk too small (%d bytes)", chunkSize)
+			}
+			fmtChunk := wav[dataStart : dataStart+chunkSize]
+			res.Channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			res.SampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			res.BitsPerSample = int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			sawFmt = true
+		case "data":
+			res.PCM = wav[dataStart : dataStart+chunkSize]
+			sawData = true
+		}
+
+		// Chunks are padded to even byte boundaries.
+		offset = dataStart + chunkSize
+		if chunkSize%2 == 1 {
+			offset++
+		}
+	}
+
+	if !sawFmt {
+		return nil, fmt.Errorf("tts: WAV stream has no fmt chunk")
+	}
+	if !sawData {
+		return nil, fmt.Errorf("tts: WAV stream has no data chunk")
+	}
+
+	return &res, nil
+}
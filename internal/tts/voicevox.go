@@ -0,0 +1,217 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const httpClientTimeout = 15 * time.Second
+
+// voicevoxCompatibleEngine drives the VOICEVOX engine HTTP API: a POST
+// /audio_query followed by a POST /synthesis. AivisSpeech exposes the same
+// API shape, so it shares this implementation and differs only in name,
+// endpoint and default speaker. New does not construct this engine for
+// CoeiroInk; that kind is rejected with an error.
+type voicevoxCompatibleEngine struct {
+	name       string
+	endpoint   string
+	speakerID  string
+	httpClient *http.Client
+
+	audioQueryLatency *latencyHistogram
+	synthesisLatency  *latencyHistogram
+}
+
+func newVoicevoxCompatibleEngine(name, endpoint, speakerID string) *voicevoxCompatibleEngine {
+	return &voicevoxCompatibleEngine{
+		name:      name,
+		endpoint:  endpoint,
+		speakerID: speakerID,
+		httpClient: &http.Client{
+			Timeout: httpClientTimeout,
+		},
+		audioQueryLatency: newLatencyHistogram(defaultLatencyBuckets),
+		synthesisLatency:  newLatencyHistogram(defaultLatencyBuckets),
+	}
+}
+
+// AudioQueryLatency returns a snapshot of this engine's audio_query HTTP
+// call latency, for use by the control server's /metrics endpoint.
+func (e *voicevoxCompatibleEngine) AudioQueryLatency() HistogramSnapshot {
+	return e.audioQueryLatency.Snapshot()
+}
+
+// SynthesisLatency returns a snapshot of this engine's synthesis HTTP call
+// latency, for use by the control server's /metrics endpoint.
+func (e *voicevoxCompatibleEngine) SynthesisLatency() HistogramSnapshot {
+	return e.synthesisLatency.Snapshot()
+}
+
+func (e *voicevoxCompatibleEngine) Name() string { return e.name }
+
+// CheckHealth performs a cheap HEAD request against the engine's /version
+// endpoint, for use by a liveness/readiness probe that wants to detect an
+// unreachable VOICEVOX/AivisSpeech backend without paying for a full
+// audio_query+synthesis round trip.
+func (e *voicevoxCompatibleEngine) CheckHealth(ctx context.Context) error {
+	versionURL, err := url.JoinPath(e.endpoint, "version")
+	if err != nil {
+		return fmt.Errorf("%s: failed to create version URL path: %w", e.name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, versionURL, nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to create version request: %w", e.name, err)
+	}
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: version request execution failed: %w", e.name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: version check failed: status %s", e.name, res.Status)
+	}
+	return nil
+}
+
+func (e *voicevoxCompatibleEngine) Synthesize(ctx context.Context, text string, opts SynthesizeOptions) (*SynthesisResult, error) {
+	speakerID := e.speakerID
+	if opts.SpeakerID != "" {
+		speakerID = opts.SpeakerID
+	}
+
+	audioQueryJSON, err := e.audioQuery(ctx, text, speakerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: audio_query failed: %w", e.name, err)
+	}
+
+	audioQueryJSON, err = applyAudioQueryOverrides(audioQueryJSON, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to apply voice profile overrides: %w", e.name, err)
+	}
+
+	wavData, err := e.synthesis(ctx, audioQueryJSON, speakerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: synthesis failed: %w", e.name, err)
+	}
+
+	result, err := ParseWAV(wavData)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse synthesized WAV: %w", e.name, err)
+	}
+	return result, nil
+}
+
+func (e *voicevoxCompatibleEngine) audioQuery(ctx context.Context, text, speakerID string) ([]byte, error) {
+	start := time.Now()
+	defer func() { e.audioQueryLatency.Observe(time.Since(start)) }()
+
+	queryURL, err := url.JoinPath(e.endpoint, "audio_query")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio_query URL path: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio_query request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Add("speaker", speakerID)
+	q.Add("text", text)
+	req.URL.RawQuery = q.Encode()
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("audio_query request execution failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("API error (audio_query): status %s, body: %s", res.Status, string(bodyBytes))
+	}
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio_query response body: %w", err)
+	}
+	return bodyBytes, nil
+}
+
+func (e *voicevoxCompatibleEngine) synthesis(ctx context.Context, audioQueryJSON []byte, speakerID string) ([]byte, error) {
+	start := time.Now()
+	defer func() { e.synthesisLatency.Observe(time.Since(start)) }()
+
+	synthesisURL, err := url.JoinPath(e.endpoint, "synthesis")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create synthesis URL path: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", synthesisURL, bytes.NewReader(audioQueryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create synthesis request: %w", err)
+	}
+
+	req.Header.Set("Accept", "audio/wav")
+	req.Header.Set("Content-Type", "application/json")
+
+	q := req.URL.Query()
+	q.Add("speaker", speakerID)
+	req.URL.RawQuery = q.Encode()
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("synthesis request execution failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("API error (synthesis): status %s, body: %s", res.Status, string(bodyBytes))
+	}
+
+	wavData, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synthesis response body: %w", err)
+	}
+	return wavData, nil
+}
+
+// applyAudioQueryOverrides mutates the speedScale/pitchScale/intonationScale/
+// volumeScale fields of an audio_query response before it is handed to
+// /synthesis, so that per-user VoiceProfile overrides take effect. Fields
+// left nil in opts are passed through untouched.
+func applyAudioQueryOverrides(audioQueryJSON []byte, opts SynthesizeOptions) ([]byte, error) {
+	if opts.SpeedScale == nil && opts.PitchScale == nil && opts.IntonationScale == nil && opts.VolumeScale == nil {
+		return audioQueryJSON, nil
+	}
+
+	var query map[string]any
+	if err := json.Unmarshal(audioQueryJSON, &query); err != nil {
+		return nil, fmt.Errorf("failed to parse audio_query response: %w", err)
+	}
+
+	if opts.SpeedScale != nil {
+		query["speedScale"] = *opts.SpeedScale
+	}
+	if opts.PitchScale != nil {
+		query["pitchScale"] = *opts.PitchScale
+	}
+	if opts.IntonationScale != nil {
+		query["intonationScale"] = *opts.IntonationScale
+	}
+	if opts.VolumeScale != nil {
+		query["volumeScale"] = *opts.VolumeScale
+	}
+
+	return json.Marshal(query)
+}
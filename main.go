@@ -5,13 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
-	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,29 +18,109 @@ import (
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
+
+	"github.com/Atis9/slack2voice/internal/textprep"
+	"github.com/Atis9/slack2voice/internal/tts"
 )
 
 const (
-	httpClientTimeout     = 15 * time.Second
 	voicevoxAPITimeout    = 20 * time.Second
-	wavHeaderSize         = 44
 	audioPlayPollInterval = 50 * time.Millisecond
-	otoSampleRate         = 24000
-	otoChannelCount       = 1
 )
 
 type Config struct {
 	SlackBotToken      string
 	SlackAppLevelToken string
-	VoicevoxEndpoint   string
-	VoicevoxSpeakerID  string
-	UserIDs            []string `json:"user_ids"`
-	ChannelIDs         []string `json:"channel_ids"`
+	TTSEngine          tts.Kind
+
+	VoicevoxEndpoint  string
+	VoicevoxSpeakerID string
+
+	AivisSpeechEndpoint  string
+	AivisSpeechSpeakerID string
+
+	OpenAIAPIKey string
+	OpenAIModel  string
+	OpenAIVoice  string
+
+	MaxQueueLength          int
+	StreamingThresholdChars int
+	EmojiMode               textprep.EmojiMode
+
+	HTTPListenAddr    string
+	HTTPControlSecret string
+	ReloadConfigPath  string
+
+	filterMu   sync.RWMutex
+	userVoices map[string]VoiceProfile
+	channelIDs []string
+}
+
+// reloadConfigFile is the on-disk JSON shape read by Config.Reload. Its
+// keys mirror the USER_IDS/CHANNEL_IDS environment variables loadConfig
+// reads at startup, so a deployment can keep the same shape across both.
+type reloadConfigFile struct {
+	UserIDs    map[string]VoiceProfile `json:"USER_IDS"`
+	ChannelIDs []string                `json:"CHANNEL_IDS"`
+}
+
+// UserVoices returns the current per-user voice mapping. Safe for concurrent
+// use with Reload.
+func (c *Config) UserVoices() map[string]VoiceProfile {
+	c.filterMu.RLock()
+	defer c.filterMu.RUnlock()
+	return c.userVoices
+}
+
+// ChannelIDs returns the current channel allow-list. Safe for concurrent use
+// with Reload.
+func (c *Config) ChannelIDs() []string {
+	c.filterMu.RLock()
+	defer c.filterMu.RUnlock()
+	return c.channelIDs
+}
+
+// Reload re-reads USER_IDS and CHANNEL_IDS from the JSON file at
+// ReloadConfigPath and swaps them in, without touching any other
+// configuration or restarting the process. Unlike the environment
+// variables loadConfig reads at startup, this file can be rewritten by an
+// external process (e.g. a mounted ConfigMap) without needing to restart
+// the container.
+func (c *Config) Reload() error {
+	data, err := os.ReadFile(c.ReloadConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read reload config %s: %w", c.ReloadConfigPath, err)
+	}
+
+	var reloaded reloadConfigFile
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		return fmt.Errorf("failed to parse reload config %s: %w", c.ReloadConfigPath, err)
+	}
+
+	c.filterMu.Lock()
+	c.userVoices = reloaded.UserIDs
+	c.channelIDs = reloaded.ChannelIDs
+	c.filterMu.Unlock()
+
+	log.Printf("INFO: Reloaded configuration from %s: %d user voice(s), %d channel ID(s)", c.ReloadConfigPath, len(reloaded.UserIDs), len(reloaded.ChannelIDs))
+	return nil
+}
+
+// VoiceProfile lets a Slack user be mapped to a specific VOICEVOX-compatible
+// speaker and to audio_query parameter overrides. Pointer fields left nil
+// fall back to the engine's default for that parameter.
+type VoiceProfile struct {
+	SpeakerID       string   `json:"speaker_id,omitempty"`
+	SpeedScale      *float64 `json:"speed_scale,omitempty"`
+	PitchScale      *float64 `json:"pitch_scale,omitempty"`
+	IntonationScale *float64 `json:"intonation_scale,omitempty"`
+	VolumeScale     *float64 `json:"volume_scale,omitempty"`
 }
 
 var (
-	audioMutex   sync.Mutex
-	globalOtoCtx *oto.Context
+	globalOtoCtx    *oto.Context
+	otoSampleRate   int
+	otoChannelCount int
 )
 
 func loadConfig() (*Config, error) {
@@ -64,9 +141,9 @@ func loadConfig() (*Config, error) {
 		missingEnvVars = append(missingEnvVars, "SLACK_APP_LEVEL_TOKEN")
 	}
 
-	userIDsJSON := os.Getenv("USER_IDS")
-	if userIDsJSON != "" {
-		if err := json.Unmarshal([]byte(userIDsJSON), &cfg.UserIDs); err != nil {
+	userVoicesJSON := os.Getenv("USER_IDS")
+	if userVoicesJSON != "" {
+		if err := json.Unmarshal([]byte(userVoicesJSON), &cfg.userVoices); err != nil {
 			return nil, fmt.Errorf("failed to parse USER_IDS: %w", err)
 		}
 	} else {
@@ -75,126 +152,139 @@ func loadConfig() (*Config, error) {
 
 	channelIDsJSON := os.Getenv("CHANNEL_IDS")
 	if channelIDsJSON != "" {
-		if err := json.Unmarshal([]byte(channelIDsJSON), &cfg.ChannelIDs); err != nil {
+		if err := json.Unmarshal([]byte(channelIDsJSON), &cfg.channelIDs); err != nil {
 			return nil, fmt.Errorf("failed to parse CHANNEL_IDS: %w", err)
 		}
 	} else {
 		log.Println("INFO: CHANNEL_IDS not set; channel filtering will not be applied.")
 	}
 
-	cfg.VoicevoxEndpoint = os.Getenv("VOICEVOX_ENDPOINT")
-	if cfg.VoicevoxEndpoint == "" {
-		missingEnvVars = append(missingEnvVars, "VOICEVOX_ENDPOINT")
-	}
-	cfg.VoicevoxSpeakerID = os.Getenv("VOICEVOX_SPEAKER_ID")
-	if cfg.VoicevoxSpeakerID == "" {
-		missingEnvVars = append(missingEnvVars, "VOICEVOX_SPEAKER_ID")
+	if maxQueueLengthStr := os.Getenv("MAX_QUEUE_LENGTH"); maxQueueLengthStr != "" {
+		maxQueueLength, err := strconv.Atoi(maxQueueLengthStr)
+		if err != nil || maxQueueLength <= 0 {
+			return nil, fmt.Errorf("invalid MAX_QUEUE_LENGTH %q: must be a positive integer", maxQueueLengthStr)
+		}
+		cfg.MaxQueueLength = maxQueueLength
 	}
 
-	if len(missingEnvVars) > 0 {
-		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missingEnvVars, ", "))
+	if streamingThresholdStr := os.Getenv("STREAMING_THRESHOLD_CHARS"); streamingThresholdStr != "" {
+		streamingThreshold, err := strconv.Atoi(streamingThresholdStr)
+		if err != nil || streamingThreshold <= 0 {
+			return nil, fmt.Errorf("invalid STREAMING_THRESHOLD_CHARS %q: must be a positive integer", streamingThresholdStr)
+		}
+		cfg.StreamingThresholdChars = streamingThreshold
 	}
 
-	return cfg, nil
-}
-
-type VoicevoxClient struct {
-	endpoint   string
-	speakerID  string
-	httpClient *http.Client
-}
-
-func NewVoicevoxClient(endpoint, speakerID string) *VoicevoxClient {
-	return &VoicevoxClient{
-		endpoint:  endpoint,
-		speakerID: speakerID,
-		httpClient: &http.Client{
-			Timeout: httpClientTimeout,
-		},
+	cfg.HTTPListenAddr = os.Getenv("HTTP_LISTEN_ADDR")
+	cfg.HTTPControlSecret = os.Getenv("HTTP_CONTROL_SECRET")
+	if cfg.HTTPListenAddr != "" && cfg.HTTPControlSecret == "" {
+		missingEnvVars = append(missingEnvVars, "HTTP_CONTROL_SECRET")
 	}
-}
-
-func (vc *VoicevoxClient) GetAudioQuery(ctx context.Context, text string) ([]byte, error) {
 
-	queryURL, err := url.JoinPath(vc.endpoint, "audio_query")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create audio_query URL path: %w", err)
+	cfg.ReloadConfigPath = os.Getenv("RELOAD_CONFIG_PATH")
+	if cfg.HTTPListenAddr != "" && cfg.ReloadConfigPath == "" {
+		missingEnvVars = append(missingEnvVars, "RELOAD_CONFIG_PATH")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", queryURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create audio_query request: %w", err)
+	cfg.EmojiMode = textprep.EmojiMode(os.Getenv("TEXTPREP_EMOJI_MODE"))
+	if cfg.EmojiMode == "" {
+		cfg.EmojiMode = textprep.EmojiModeSpeak
 	}
 
-	q := req.URL.Query()
-	q.Add("speaker", vc.speakerID)
-	q.Add("text", text)
-	req.URL.RawQuery = q.Encode()
-
-	res, err := vc.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("audio_query request execution failed: %w", err)
+	cfg.TTSEngine = tts.Kind(os.Getenv("TTS_ENGINE"))
+	if cfg.TTSEngine == "" {
+		cfg.TTSEngine = tts.KindVoicevox
+		log.Println("INFO: TTS_ENGINE not set; defaulting to \"voicevox\".")
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("VOICEVOX API error (audio_query): status %s, body: %s", res.Status, string(bodyBytes))
+	switch cfg.TTSEngine {
+	case tts.KindVoicevox:
+		cfg.VoicevoxEndpoint = os.Getenv("VOICEVOX_ENDPOINT")
+		if cfg.VoicevoxEndpoint == "" {
+			missingEnvVars = append(missingEnvVars, "VOICEVOX_ENDPOINT")
+		}
+		cfg.VoicevoxSpeakerID = os.Getenv("VOICEVOX_SPEAKER_ID")
+		if cfg.VoicevoxSpeakerID == "" {
+			missingEnvVars = append(missingEnvVars, "VOICEVOX_SPEAKER_ID")
+		}
+	case tts.KindAivisSpeech:
+		cfg.AivisSpeechEndpoint = os.Getenv("AIVISSPEECH_ENDPOINT")
+		if cfg.AivisSpeechEndpoint == "" {
+			missingEnvVars = append(missingEnvVars, "AIVISSPEECH_ENDPOINT")
+		}
+		cfg.AivisSpeechSpeakerID = os.Getenv("AIVISSPEECH_SPEAKER_ID")
+		if cfg.AivisSpeechSpeakerID == "" {
+			missingEnvVars = append(missingEnvVars, "AIVISSPEECH_SPEAKER_ID")
+		}
+	case tts.KindOpenAI:
+		cfg.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
+		if cfg.OpenAIAPIKey == "" {
+			missingEnvVars = append(missingEnvVars, "OPENAI_API_KEY")
+		}
+		cfg.OpenAIModel = os.Getenv("OPENAI_TTS_MODEL")
+		cfg.OpenAIVoice = os.Getenv("OPENAI_TTS_VOICE")
+	default:
+		// Unknown/unimplemented engines (e.g. coeiroink, elevenlabs) are
+		// still recorded here and rejected later by tts.New.
 	}
 
-	bodyBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read audio_query response body: %w", err)
+	if len(missingEnvVars) > 0 {
+		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missingEnvVars, ", "))
 	}
-	return bodyBytes, nil
+
+	return cfg, nil
 }
 
-func (vc *VoicevoxClient) Synthesis(ctx context.Context, audioQueryJSON []byte) ([]byte, error) {
-	synthesisURL, err := url.JoinPath(vc.endpoint, "synthesis")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create synthesis URL path: %w", err)
+// ensureOtoContext (re)creates the global oto context if it has not been
+// initialized yet, or if the requested format differs from the currently
+// configured one. Engines are free to emit audio at different sample rates
+// and channel counts, so playback must adapt rather than assume a single
+// fixed format.
+func ensureOtoContext(sampleRate, channelCount int) error {
+	if globalOtoCtx != nil && otoSampleRate == sampleRate && otoChannelCount == channelCount {
+		return nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", synthesisURL, bytes.NewReader(audioQueryJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create synthesis request: %w", err)
-	}
-
-	req.Header.Set("Accept", "audio/wav")
-	req.Header.Set("Content-Type", "application/json")
-
-	q := req.URL.Query()
-	q.Add("speaker", vc.speakerID)
-	req.URL.RawQuery = q.Encode()
+	op := &oto.NewContextOptions{}
+	op.SampleRate = sampleRate
+	op.ChannelCount = channelCount
+	op.Format = oto.FormatSignedInt16LE
 
-	res, err := vc.httpClient.Do(req)
+	ctx, readyChan, err := oto.NewContext(op)
 	if err != nil {
-		return nil, fmt.Errorf("synthesis request execution failed: %w", err)
+		return fmt.Errorf("failed to create oto context (sampleRate=%d, channels=%d): %w", sampleRate, channelCount, err)
 	}
-	defer res.Body.Close()
+	<-readyChan
 
-	if res.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(res.Body)
-		return nil, fmt.Errorf("VOICEVOX API error (synthesis): status %s, body: %s", res.Status, string(bodyBytes))
-	}
+	globalOtoCtx = ctx
+	otoSampleRate = sampleRate
+	otoChannelCount = channelCount
+	log.Printf("INFO: Oto context (re)initialized for sampleRate=%d, channels=%d", sampleRate, channelCount)
+	return nil
+}
 
-	wavData, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read synthesis response body: %w", err)
+// playAudio plays result through the global oto context. If ctx is canceled
+// (e.g. via a /skip command or a :fast_forward: reaction) playback stops
+// early instead of running to completion.
+func playAudio(ctx context.Context, result *tts.SynthesisResult) error {
+	if result.BitsPerSample != 0 && result.BitsPerSample != 16 {
+		return fmt.Errorf("unsupported bit depth %d; only 16-bit PCM is supported", result.BitsPerSample)
 	}
-	return wavData, nil
-}
 
-func playAudio(pcmData []byte) error {
-	if globalOtoCtx == nil {
-		return fmt.Errorf("global oto context is not initialized")
+	if err := ensureOtoContext(result.SampleRate, result.Channels); err != nil {
+		return err
 	}
-	player := globalOtoCtx.NewPlayer(bytes.NewReader(pcmData))
+
+	player := globalOtoCtx.NewPlayer(bytes.NewReader(result.PCM))
 	defer player.Close()
 
 	player.Play()
 	for player.IsPlaying() {
-		time.Sleep(audioPlayPollInterval)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(audioPlayPollInterval):
+		}
 	}
 	return nil
 }
@@ -207,19 +297,6 @@ func main() {
 		log.Fatalf("FATAL: Error loading configuration: %v", err)
 	}
 
-	op := &oto.NewContextOptions{}
-	op.SampleRate = otoSampleRate
-	op.ChannelCount = otoChannelCount
-	op.Format = oto.FormatSignedInt16LE
-
-	var readyChan <-chan struct{}
-	globalOtoCtx, readyChan, err = oto.NewContext(op)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to create global oto context: %v", err)
-	}
-	<-readyChan
-	log.Println("INFO: Global Oto context initialized successfully.")
-
 	slackAPI := slack.New(
 		cfg.SlackBotToken,
 		slack.OptionAppLevelToken(cfg.SlackAppLevelToken),
@@ -230,43 +307,70 @@ func main() {
 	}
 	log.Println("INFO: Slack API authentication successful.")
 
-	if len(cfg.UserIDs) > 0 {
+	if userVoices := cfg.UserVoices(); len(userVoices) > 0 {
 		log.Println("INFO: Will attempt to read out messages from the following User IDs:")
-		for _, userID := range cfg.UserIDs {
+		for userID, profile := range userVoices {
 			userInfo, err := slackAPI.GetUserInfo(userID)
 			if err != nil {
 				log.Printf("WARNING: Could not fetch info for target User ID %s: %v. Bot will still try to match this ID.", userID, err)
 				continue
 			}
-			log.Printf("  - Target User: ID=%s, Name=%s", userInfo.ID, userInfo.Profile.DisplayName)
+			log.Printf("  - Target User: ID=%s, Name=%s, SpeakerID=%q", userInfo.ID, userInfo.Profile.DisplayName, profile.SpeakerID)
 		}
 	} else {
 		log.Println("INFO: No specific UserIDs configured. User filtering will not be applied.")
 	}
 
-	if len(cfg.ChannelIDs) > 0 {
+	if channelIDs := cfg.ChannelIDs(); len(channelIDs) > 0 {
 		log.Println("INFO: Messages will be filtered to the following Channel IDs:")
-		for _, channelID := range cfg.ChannelIDs {
+		for _, channelID := range channelIDs {
 			log.Printf("  - Target Channel: ID=%s", channelID)
 		}
 	} else {
 		log.Println("INFO: No specific ChannelIDs configured. Channel filtering will not be applied.")
 	}
 
-	vvClient := NewVoicevoxClient(cfg.VoicevoxEndpoint, cfg.VoicevoxSpeakerID)
-	log.Printf("INFO: VoicevoxClient initialized for endpoint %s with speaker ID %s", cfg.VoicevoxEndpoint, cfg.VoicevoxSpeakerID)
+	engine, err := tts.New(cfg.TTSEngine, tts.Config{
+		VoicevoxEndpoint:    cfg.VoicevoxEndpoint,
+		VoicevoxSpeaker:     cfg.VoicevoxSpeakerID,
+		AivisSpeechEndpoint: cfg.AivisSpeechEndpoint,
+		AivisSpeechSpeaker:  cfg.AivisSpeechSpeakerID,
+		OpenAIAPIKey:        cfg.OpenAIAPIKey,
+		OpenAIModel:         cfg.OpenAIModel,
+		OpenAIVoice:         cfg.OpenAIVoice,
+	})
+	if err != nil {
+		log.Fatalf("FATAL: Failed to initialize TTS engine: %v", err)
+	}
+	log.Printf("INFO: TTS engine %q initialized", engine.Name())
+
+	textRenderer := textprep.New(slackAPI, slackAPI, textprep.Options{EmojiMode: cfg.EmojiMode})
+
+	queue := newPlaybackQueue(slackAPI, engine, cfg.MaxQueueLength, cfg.StreamingThresholdChars)
+	go queue.Run(context.Background())
+
+	msgMetrics := &messageMetrics{}
+
+	if cfg.HTTPListenAddr != "" {
+		controlSrv := newControlServer(cfg.HTTPListenAddr, cfg.HTTPControlSecret, queue, cfg, slackAPI, engine, msgMetrics)
+		if err := controlSrv.Start(); err != nil {
+			log.Fatalf("FATAL: Failed to start control server: %v", err)
+		}
+	} else {
+		log.Println("INFO: HTTP_LISTEN_ADDR not set; control/metrics server disabled.")
+	}
 
 	socketClient := socketmode.New(slackAPI)
 
 	log.Println("INFO: Starting Slack event listener...")
-	go runEventLoop(socketClient, slackAPI, cfg, vvClient)
+	go runEventLoop(socketClient, slackAPI, cfg, queue, textRenderer, msgMetrics)
 
 	if err := socketClient.Run(); err != nil {
 		log.Fatalf("FATAL: Socketmode client exited with error: %v", err)
 	}
 }
 
-func runEventLoop(client *socketmode.Client, slackAPI *slack.Client, cfg *Config, vvClient *VoicevoxClient) {
+func runEventLoop(client *socketmode.Client, slackAPI *slack.Client, cfg *Config, queue *playbackQueue, textRenderer *textprep.Renderer, msgMetrics *messageMetrics) {
 	for envelope := range client.Events {
 		switch envelope.Type {
 		case socketmode.EventTypeConnecting:
@@ -287,31 +391,66 @@ func runEventLoop(client *socketmode.Client, slackAPI *slack.Client, cfg *Config
 
 			switch eventsAPIEvent.Type {
 			case slackevents.CallbackEvent:
-				go processCallbackEvent(slackAPI, cfg, vvClient, eventsAPIEvent.InnerEvent)
+				go processCallbackEvent(slackAPI, cfg, queue, textRenderer, msgMetrics, eventsAPIEvent.InnerEvent)
 			}
+		case socketmode.EventTypeSlashCommand:
+			cmd, ok := envelope.Data.(slack.SlashCommand)
+			if !ok {
+				log.Printf("WARNING: Received unexpected data type for SlashCommand: %T", envelope.Data)
+				client.Ack(*envelope.Request)
+				continue
+			}
+			client.Ack(*envelope.Request, handleSlashCommand(queue, cmd))
 		}
 	}
 }
 
-func processCallbackEvent(slackAPI *slack.Client, cfg *Config, vvClient *VoicevoxClient, innerEvent slackevents.EventsAPIInnerEvent) {
+// handleSlashCommand handles the /skip and /mute control commands and
+// returns the ephemeral acknowledgement payload to send back to Slack.
+func handleSlashCommand(queue *playbackQueue, cmd slack.SlashCommand) map[string]any {
+	switch cmd.Command {
+	case "/skip":
+		queue.Skip()
+		return map[string]any{"response_type": "ephemeral", "text": "Skipped the current message."}
+	case "/mute":
+		queue.SetMuted(true)
+		return map[string]any{"response_type": "ephemeral", "text": "Playback paused. Use /unmute to resume."}
+	case "/unmute":
+		queue.SetMuted(false)
+		return map[string]any{"response_type": "ephemeral", "text": "Playback resumed."}
+	default:
+		return map[string]any{"response_type": "ephemeral", "text": fmt.Sprintf("Unknown command: %s", cmd.Command)}
+	}
+}
+
+func processCallbackEvent(slackAPI *slack.Client, cfg *Config, queue *playbackQueue, textRenderer *textprep.Renderer, msgMetrics *messageMetrics, innerEvent slackevents.EventsAPIInnerEvent) {
 	switch event := innerEvent.Data.(type) {
 	case *slackevents.MessageEvent:
 		if event.User == "" || event.BotID != "" || event.SubType == "bot_message" || event.SubType == "slackbot_response" {
 			return
 		}
-		handleMessageEvent(slackAPI, cfg, vvClient, event)
+		handleMessageEvent(slackAPI, cfg, queue, textRenderer, msgMetrics, event)
+	case *slackevents.ReactionAddedEvent:
+		if event.Reaction == "fast_forward" && queue.MatchesCurrent(event.Item.Channel, event.Item.Timestamp) {
+			log.Printf("INFO: Skip requested via :fast_forward: reaction from UserID %s", event.User)
+			queue.Skip()
+		}
 	}
 }
 
-func handleMessageEvent(slackAPI *slack.Client, cfg *Config, vvClient *VoicevoxClient, event *slackevents.MessageEvent) {
-	if len(cfg.UserIDs) > 0 {
-		if !slices.Contains(cfg.UserIDs, event.User) {
-			return
-		}
+func handleMessageEvent(slackAPI *slack.Client, cfg *Config, queue *playbackQueue, textRenderer *textprep.Renderer, msgMetrics *messageMetrics, event *slackevents.MessageEvent) {
+	msgMetrics.received.Add(1)
+
+	userVoices := cfg.UserVoices()
+	voiceProfile, hasProfile := userVoices[event.User]
+	if len(userVoices) > 0 && !hasProfile {
+		msgMetrics.filtered.Add(1)
+		return
 	}
 
-	if len(cfg.ChannelIDs) > 0 {
-		if !slices.Contains(cfg.ChannelIDs, event.Channel) {
+	if channelIDs := cfg.ChannelIDs(); len(channelIDs) > 0 {
+		if !slices.Contains(channelIDs, event.Channel) {
+			msgMetrics.filtered.Add(1)
 			return
 		}
 	}
@@ -319,14 +458,7 @@ func handleMessageEvent(slackAPI *slack.Client, cfg *Config, vvClient *VoicevoxC
 	ctx, cancel := context.WithTimeout(context.Background(), voicevoxAPITimeout)
 	defer cancel()
 
-	re := regexp.MustCompile(`<([^|>]+?)(\|(.+?))?>`)
-	processedText := re.ReplaceAllStringFunc(event.Text, func(match string) string {
-		submatches := re.FindStringSubmatch(match)
-		if len(submatches) > 3 && submatches[3] != "" {
-			return submatches[3]
-		}
-		return ""
-	})
+	processedText := textRenderer.Render(ctx, event.Text)
 
 	var displayName string
 	userInfo, err := slackAPI.GetUserInfoContext(ctx, event.User)
@@ -347,51 +479,19 @@ func handleMessageEvent(slackAPI *slack.Client, cfg *Config, vvClient *VoicevoxC
 	}
 
 	textToSpeak := fmt.Sprintf("%sさんからのメッセージ。%s", displayName, processedText)
-	log.Printf("INFO: Preparing to speak: \"%s\"", textToSpeak)
-
-	audioQueryJSON, err := vvClient.GetAudioQuery(ctx, textToSpeak)
-	if err != nil {
-		log.Printf("ERROR: Failed to get audio query for \"%s\": %v", textToSpeak, err)
-		return
-	}
-
-	wavData, err := vvClient.Synthesis(ctx, audioQueryJSON)
-	if err != nil {
-		log.Printf("ERROR: Failed to synthesize audio for \"%s\": %v", textToSpeak, err)
-		return
-	}
-
-	if len(wavData) <= wavHeaderSize {
-		log.Printf("ERROR: Synthesized WAV data is too short (length %d) for \"%s\"", len(wavData), textToSpeak)
-		return
-	}
-
-	pcmDataSize := len(wavData) - wavHeaderSize
-	log.Printf("INFO: Playing audio for \"%s\" (WAV size: %d bytes, PCM size: %d bytes)", textToSpeak, len(wavData), pcmDataSize)
-
-	audioMutex.Lock()
-	defer audioMutex.Unlock()
-
-	itemRef := slack.NewRefToMessage(event.Channel, event.TimeStamp)
-	reactionName := "speaker"
-
-	errAddReaction := slackAPI.AddReactionContext(ctx, reactionName, itemRef)
-	if errAddReaction != nil {
-		log.Printf("WARNING: Failed to add reaction ':%s:' to message TS %s in channel %s: %v", reactionName, event.TimeStamp, event.Channel, errAddReaction)
-	} else {
-		log.Printf("INFO: Added reaction ':%s:' to message TS %s in channel %s", reactionName, event.TimeStamp, event.Channel)
-	}
-
-	if err := playAudio(wavData[wavHeaderSize:]); err != nil {
-		log.Printf("ERROR: Failed to play audio for \"%s\": %v", textToSpeak, err)
-	} else {
-		log.Printf("INFO: Finished playing audio for \"%s\"", textToSpeak)
-	}
-
-	errRemoveReaction := slackAPI.RemoveReactionContext(ctx, reactionName, itemRef)
-	if errRemoveReaction != nil {
-		log.Printf("WARNING: Failed to remove reaction ':%s:' to message TS %s in channel %s: %v", reactionName, event.TimeStamp, event.Channel, errRemoveReaction)
-	} else {
-		log.Printf("INFO: Removed reaction ':%s:' to message TS %s in channel %s", reactionName, event.TimeStamp, event.Channel)
-	}
+	log.Printf("INFO: Queuing message for playback: \"%s\"", textToSpeak)
+
+	queue.Enqueue(&synthJob{
+		UserID:    event.User,
+		Channel:   event.Channel,
+		TimeStamp: event.TimeStamp,
+		Text:      textToSpeak,
+		Opts: tts.SynthesizeOptions{
+			SpeakerID:       voiceProfile.SpeakerID,
+			SpeedScale:      voiceProfile.SpeedScale,
+			PitchScale:      voiceProfile.PitchScale,
+			IntonationScale: voiceProfile.IntonationScale,
+			VolumeScale:     voiceProfile.VolumeScale,
+		},
+	})
 }
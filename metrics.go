@@ -0,0 +1,26 @@
+package main
+
+import "sync/atomic"
+
+// messageMetrics tracks Slack message events as handleMessageEvent sees
+// them, upstream of playbackQueue's own counters (which only ever see
+// messages that already passed the USER_IDS/CHANNEL_IDS allow-list and were
+// enqueued).
+type messageMetrics struct {
+	received atomic.Int64
+	filtered atomic.Int64
+}
+
+// messageMetricsSnapshot is a point-in-time read of messageMetrics, used by
+// the control server's /metrics endpoint.
+type messageMetricsSnapshot struct {
+	Received int64
+	Filtered int64
+}
+
+func (m *messageMetrics) Snapshot() messageMetricsSnapshot {
+	return messageMetricsSnapshot{
+		Received: m.received.Load(),
+		Filtered: m.filtered.Load(),
+	}
+}
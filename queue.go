@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+
+	"github.com/Atis9/slack2voice/internal/tts"
+)
+
+const (
+	defaultMaxQueueLength        = 20
+	defaultStreamingThresholdLen = 50
+	streamingChunkBufferSize     = 2
+)
+
+// synthJob is one utterance queued for text-to-speech playback.
+type synthJob struct {
+	UserID    string
+	Channel   string
+	TimeStamp string
+	Text      string
+	Opts      tts.SynthesizeOptions
+}
+
+// playbackQueue serializes synthesis and playback of synthJobs, replacing
+// the old audioMutex-based serialization. A single worker goroutine (Run)
+// drains the queue so at most one message is ever being synthesized or
+// played at a time. It additionally supports:
+//   - coalescing consecutive messages from the same user in the same
+//     channel into one utterance instead of speaking them one-by-one,
+//   - a bounded queue length with drop-oldest backpressure,
+//   - skipping the utterance currently playing, and
+//   - pausing processing via /mute.
+type playbackQueue struct {
+	slackAPI *slack.Client
+	engine   tts.Engine
+
+	mu            sync.Mutex
+	cond          *sync.Cond
+	items         []*synthJob
+	maxLen        int
+	muted         bool
+	cancelCurrent context.CancelFunc
+	currentRef    slack.ItemRef
+
+	streamingThresholdChars int
+
+	jobsEnqueued  atomic.Int64
+	jobsDropped   atomic.Int64
+	jobsProcessed atomic.Int64
+	jobsFailed    atomic.Int64
+}
+
+func newPlaybackQueue(slackAPI *slack.Client, engine tts.Engine, maxLen, streamingThresholdChars int) *playbackQueue {
+	if maxLen <= 0 {
+		maxLen = defaultMaxQueueLength
+	}
+	if streamingThresholdChars <= 0 {
+		streamingThresholdChars = defaultStreamingThresholdLen
+	}
+	q := &playbackQueue{
+		slackAPI:                slackAPI,
+		engine:                  engine,
+		maxLen:                  maxLen,
+		streamingThresholdChars: streamingThresholdChars,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Enqueue adds a job to the queue. If the most recently queued job is from
+// the same user in the same channel and hasn't started playing yet, the new
+// text is coalesced into it rather than queued separately. If the queue is
+// at capacity, the oldest pending job is dropped to make room.
+func (q *playbackQueue) Enqueue(job *synthJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n := len(q.items); n > 0 {
+		tail := q.items[n-1]
+		if tail.UserID == job.UserID && tail.Channel == job.Channel {
+			tail.Text = tail.Text + "。" + job.Text
+			tail.TimeStamp = job.TimeStamp
+			tail.Opts = job.Opts
+			q.cond.Signal()
+			return
+		}
+	}
+
+	q.items = append(q.items, job)
+	q.jobsEnqueued.Add(1)
+	if len(q.items) > q.maxLen {
+		dropped := q.items[0]
+		q.items = q.items[1:]
+		q.jobsDropped.Add(1)
+		log.Printf("WARNING: Playback queue exceeded max length %d; dropped oldest message from UserID %s in channel %s", q.maxLen, dropped.UserID, dropped.Channel)
+	}
+	q.cond.Signal()
+}
+
+// Len returns the number of jobs currently waiting in the queue (excluding
+// the job being played, if any).
+func (q *playbackQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Muted reports whether queue processing is currently paused via /mute.
+func (q *playbackQueue) Muted() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.muted
+}
+
+// queueMetrics is a point-in-time snapshot of playbackQueue counters, used by
+// the control server's /metrics endpoint.
+type queueMetrics struct {
+	QueueLength   int
+	Muted         bool
+	JobsEnqueued  int64
+	JobsDropped   int64
+	JobsProcessed int64
+	JobsFailed    int64
+}
+
+func (q *playbackQueue) Metrics() queueMetrics {
+	return queueMetrics{
+		QueueLength:   q.Len(),
+		Muted:         q.Muted(),
+		JobsEnqueued:  q.jobsEnqueued.Load(),
+		JobsDropped:   q.jobsDropped.Load(),
+		JobsProcessed: q.jobsProcessed.Load(),
+		JobsFailed:    q.jobsFailed.Load(),
+	}
+}
+
+// SetMuted pauses or resumes queue processing. Queued jobs are kept and will
+// play once unmuted; the job currently playing (if any) is unaffected.
+func (q *playbackQueue) SetMuted(muted bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.muted = muted
+	q.cond.Broadcast()
+}
+
+// Skip cancels the utterance currently being played, if any.
+func (q *playbackQueue) Skip() {
+	q.mu.Lock()
+	cancel := q.cancelCurrent
+	q.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// MatchesCurrent reports whether channel/timestamp identify the message
+// currently being read aloud. Used to gate reaction-triggered skips to the
+// message actually playing.
+func (q *playbackQueue) MatchesCurrent(channel, timestamp string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.cancelCurrent != nil && q.currentRef.Channel == channel && q.currentRef.Timestamp == timestamp
+}
+
+// Run drains the queue until ctx is canceled, synthesizing and playing one
+// job at a time.
+func (q *playbackQueue) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}()
+
+	for {
+		q.mu.Lock()
+		for (len(q.items) == 0 || q.muted) && ctx.Err() == nil {
+			q.cond.Wait()
+		}
+		if ctx.Err() != nil {
+			q.mu.Unlock()
+			return
+		}
+		job := q.items[0]
+		q.items = q.items[1:]
+		q.mu.Unlock()
+
+		q.process(ctx, job)
+	}
+}
+
+func (q *playbackQueue) process(parentCtx context.Context, job *synthJob) {
+	log.Printf("INFO: Preparing to speak: \"%s\"", job.Text)
+
+	itemRef := slack.NewRefToMessage(job.Channel, job.TimeStamp)
+	reactionName := "speaker"
+
+	reactionCtx, reactionCancel := context.WithTimeout(parentCtx, voicevoxAPITimeout)
+	if err := q.slackAPI.AddReactionContext(reactionCtx, reactionName, itemRef); err != nil {
+		log.Printf("WARNING: Failed to add reaction ':%s:' to message TS %s in channel %s: %v", reactionName, job.TimeStamp, job.Channel, err)
+	}
+	reactionCancel()
+
+	playCtx, playCancel := context.WithCancel(parentCtx)
+	q.mu.Lock()
+	q.cancelCurrent = playCancel
+	q.currentRef = itemRef
+	q.mu.Unlock()
+
+	var playErr error
+	chunks := splitSentences(job.Text)
+	if len(chunks) > 1 && utf8.RuneCountInString(job.Text) >= q.streamingThresholdChars {
+		playErr = q.synthesizeAndPlayStreaming(parentCtx, playCtx, job, chunks)
+	} else {
+		playErr = q.synthesizeAndPlayOnce(parentCtx, playCtx, job)
+	}
+
+	q.mu.Lock()
+	q.cancelCurrent = nil
+	q.currentRef = slack.ItemRef{}
+	q.mu.Unlock()
+	playCancel()
+
+	if playErr != nil {
+		q.jobsFailed.Add(1)
+		log.Printf("ERROR: Failed to play audio for \"%s\": %v", job.Text, playErr)
+	} else {
+		q.jobsProcessed.Add(1)
+		log.Printf("INFO: Finished playing audio for \"%s\"", job.Text)
+	}
+
+	reactionCtx, reactionCancel = context.WithTimeout(parentCtx, voicevoxAPITimeout)
+	if err := q.slackAPI.RemoveReactionContext(reactionCtx, reactionName, itemRef); err != nil {
+		log.Printf("WARNING: Failed to remove reaction ':%s:' to message TS %s in channel %s: %v", reactionName, job.TimeStamp, job.Channel, err)
+	}
+	reactionCancel()
+}
+
+// synthesizeAndPlayOnce synthesizes job.Text as a single utterance and plays
+// it back. This is the fallback path for text shorter than
+// streamingThresholdChars, where the latency of synthesizing the whole
+// message up front is not worth the complexity of chunked streaming.
+func (q *playbackQueue) synthesizeAndPlayOnce(synthParent, playCtx context.Context, job *synthJob) error {
+	synthCtx, cancel := context.WithTimeout(synthParent, voicevoxAPITimeout)
+	defer cancel()
+
+	result, err := q.engine.Synthesize(synthCtx, job.Text, job.Opts)
+	if err != nil {
+		return fmt.Errorf("failed to synthesize audio: %w", err)
+	}
+	log.Printf("INFO: Playing audio for \"%s\" (PCM size: %d bytes, sampleRate: %d, channels: %d)",
+		job.Text, len(result.PCM), result.SampleRate, result.Channels)
+
+	return playAudio(playCtx, result)
+}
+
+// synthesizeAndPlayStreaming splits job.Text into sentence-sized chunks and
+// pipelines their synthesis with playback: a producer goroutine synthesizes
+// chunk N+1 while the consumer (this goroutine) plays chunk N, so long
+// messages start speaking before the whole message has been synthesized.
+func (q *playbackQueue) synthesizeAndPlayStreaming(synthParent, playCtx context.Context, job *synthJob, chunks []string) error {
+	results := make(chan *tts.SynthesisResult, streamingChunkBufferSize)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(results)
+		for _, chunk := range chunks {
+			synthCtx, cancel := context.WithTimeout(synthParent, voicevoxAPITimeout)
+			result, err := q.engine.Synthesize(synthCtx, chunk, job.Opts)
+			cancel()
+			if err != nil {
+				errCh <- fmt.Errorf("failed to synthesize audio chunk %q: %w", chunk, err)
+				return
+			}
+			select {
+			case results <- result:
+			case <-playCtx.Done():
+				return
+			}
+		}
+	}()
+
+	for result := range results {
+		log.Printf("INFO: Playing audio chunk for \"%s\" (PCM size: %d bytes, sampleRate: %d, channels: %d)",
+			job.Text, len(result.PCM), result.SampleRate, result.Channels)
+		if err := playAudio(playCtx, result); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// splitSentences splits text into Japanese sentence-sized chunks, breaking
+// after each 。！？ or newline and keeping the delimiter attached to the
+// preceding chunk. Empty chunks (e.g. from trailing whitespace) are dropped.
+func splitSentences(text string) []string {
+	var chunks []string
+	var b strings.Builder
+	for _, r := range text {
+		b.WriteRune(r)
+		switch r {
+		case '。', '！', '？', '\n':
+			if s := strings.TrimSpace(b.String()); s != "" {
+				chunks = append(chunks, s)
+			}
+			b.Reset()
+		}
+	}
+	if s := strings.TrimSpace(b.String()); s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}